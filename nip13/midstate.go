@@ -0,0 +1,165 @@
+package nip13
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/bits"
+	"strconv"
+
+	nostr "github.com/nbd-wtf/go-nostr"
+)
+
+// nonceMarker is written as the nonce tag's value while building the event's
+// canonical serialization, purely so its byte offset can be located with
+// bytes.Index. It is long and unusual enough that it will never occur
+// naturally in a pubkey, a timestamp, other tags or the content, and it is
+// replaced with the real nonce before any event is returned to a caller.
+const nonceMarker = "nip13-nonce-midstate-marker-9f2e7c41"
+
+// sha256K are the round constants from FIPS 180-4.
+var sha256K = [64]uint32{
+	0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5, 0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+	0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3, 0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+	0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc, 0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+	0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7, 0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+	0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13, 0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+	0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3, 0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+	0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5, 0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+	0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208, 0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2,
+}
+
+// sha256Init is the SHA-256 initial hash value, h0 through h7.
+var sha256Init = [8]uint32{
+	0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a,
+	0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19,
+}
+
+// sha256Block runs the SHA-256 compression function over p, which must hold a
+// whole number of 64-byte blocks, updating h in place. It is the only piece
+// of SHA-256 a pre-hasher needs: everything else (padding, length, the final
+// byte swap) happens once in sha256Finalize.
+func sha256Block(h *[8]uint32, p []byte) {
+	var w [64]uint32
+	for len(p) >= 64 {
+		for i := 0; i < 16; i++ {
+			j := i * 4
+			w[i] = uint32(p[j])<<24 | uint32(p[j+1])<<16 | uint32(p[j+2])<<8 | uint32(p[j+3])
+		}
+		for i := 16; i < 64; i++ {
+			v1 := w[i-2]
+			t1 := bits.RotateLeft32(v1, -17) ^ bits.RotateLeft32(v1, -19) ^ (v1 >> 10)
+			v2 := w[i-15]
+			t2 := bits.RotateLeft32(v2, -7) ^ bits.RotateLeft32(v2, -18) ^ (v2 >> 3)
+			w[i] = t1 + w[i-7] + t2 + w[i-16]
+		}
+
+		a, b, c, d, e, f, g, hh := h[0], h[1], h[2], h[3], h[4], h[5], h[6], h[7]
+
+		for i := 0; i < 64; i++ {
+			t1 := hh + (bits.RotateLeft32(e, -6) ^ bits.RotateLeft32(e, -11) ^ bits.RotateLeft32(e, -25)) + ((e & f) ^ (^e & g)) + sha256K[i] + w[i]
+			t2 := (bits.RotateLeft32(a, -2) ^ bits.RotateLeft32(a, -13) ^ bits.RotateLeft32(a, -22)) + ((a & b) ^ (a & c) ^ (b & c))
+			hh, g, f, e, d, c, b, a = g, f, e, d+t1, c, b, a, t1+t2
+		}
+
+		h[0] += a
+		h[1] += b
+		h[2] += c
+		h[3] += d
+		h[4] += e
+		h[5] += f
+		h[6] += g
+		h[7] += hh
+
+		p = p[64:]
+	}
+}
+
+// sha256Finalize completes a SHA-256 digest given a midstate h reached after
+// absorbing totalBefore bytes (a multiple of 64) and the remaining tail of
+// the message, padding and appending the bit length exactly as a standalone
+// sha256.Sum256 would.
+func sha256Finalize(h [8]uint32, totalBefore int, tail []byte) [32]byte {
+	bitLen := uint64(totalBefore+len(tail)) * 8
+
+	buf := make([]byte, 0, len(tail)+72)
+	buf = append(buf, tail...)
+	buf = append(buf, 0x80)
+	for len(buf)%64 != 56 {
+		buf = append(buf, 0)
+	}
+	buf = binary.BigEndian.AppendUint64(buf, bitLen)
+
+	sha256Block(&h, buf)
+
+	var digest [32]byte
+	for i, v := range h {
+		binary.BigEndian.PutUint32(digest[i*4:], v)
+	}
+	return digest
+}
+
+// DifficultyBytes is like Difficulty but counts leading zero bits directly on
+// a raw 32-byte digest, skipping the hex encode/decode round-trip Difficulty
+// needs when all that's on hand is an event ID string.
+func DifficultyBytes(id [32]byte) int {
+	var zeros int
+	for _, b := range id {
+		if b == 0 {
+			zeros += 8
+			continue
+		}
+		zeros += bits.LeadingZeros8(b)
+		break
+	}
+	return zeros
+}
+
+// nonceMiner caches the SHA-256 midstate of the invariant portion of an
+// event's serialization (everything up to the nonce tag's value) so that
+// probing a candidate nonce only has to hash the handful of bytes that
+// actually differ between attempts: the nonce's ASCII digits and whatever of
+// the serialization follows it.
+type nonceMiner struct {
+	state  [8]uint32
+	before int    // bytes already folded into state, always a multiple of 64
+	carry  []byte // bytes of the trailing partial block, strictly before the nonce
+	suffix []byte // bytes of the serialization strictly after the nonce value
+}
+
+// newNonceMiner appends a "nonce" tag committing to targetDifficulty onto
+// event, serializes it once, and splits that serialization around the nonce
+// value to build a nonceMiner. It returns the tag alongside the miner so the
+// caller can stamp in the winning nonce once one is found, exactly as
+// Generate already did before mining was pre-hashed.
+func newNonceMiner(event *nostr.Event, targetDifficulty int) (*nonceMiner, nostr.Tag) {
+	tag := nostr.Tag{"nonce", nonceMarker, strconv.Itoa(targetDifficulty)}
+	event.Tags = append(event.Tags, tag)
+
+	serialized := event.Serialize()
+	idx := bytes.Index(serialized, []byte(nonceMarker))
+	if idx < 0 {
+		// the marker must always be present right after being serialized;
+		// this would only trip if nostr.Event.Serialize changed shape.
+		panic("nip13: could not locate nonce marker in event serialization")
+	}
+
+	prefix := serialized[:idx]
+	suffix := append([]byte(nil), serialized[idx+len(nonceMarker):]...)
+
+	before := len(prefix) - len(prefix)%64
+	state := sha256Init
+	sha256Block(&state, prefix[:before])
+	carry := append([]byte(nil), prefix[before:]...)
+
+	return &nonceMiner{state: state, before: before, carry: carry, suffix: suffix}, tag
+}
+
+// difficulty reports the leading zero bits of the event ID that would result
+// from stamping nonce into the tag this miner was built for.
+func (m *nonceMiner) difficulty(nonce uint64) int {
+	tail := make([]byte, 0, len(m.carry)+20+len(m.suffix))
+	tail = append(tail, m.carry...)
+	tail = strconv.AppendUint(tail, nonce, 10)
+	tail = append(tail, m.suffix...)
+	return DifficultyBytes(sha256Finalize(m.state, m.before, tail))
+}
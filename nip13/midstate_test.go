@@ -0,0 +1,58 @@
+package nip13
+
+import (
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"testing"
+
+	nostr "github.com/nbd-wtf/go-nostr"
+)
+
+func testEvent() *nostr.Event {
+	return &nostr.Event{
+		PubKey:    strings.Repeat("a", 64),
+		CreatedAt: nostr.Now(),
+		Kind:      1,
+		Tags:      nostr.Tags{{"t", "nip13"}},
+		Content:   "testing the pre-hashed nonce miner against the reference Difficulty path",
+	}
+}
+
+// TestNonceMinerMatchesDifficulty exercises the invariant the whole
+// pre-hasher depends on: that the nonceMarker survives event.Serialize()
+// unescaped, so splitting the serialization around it and mining from the
+// cached midstate produces exactly the same difficulty, for every nonce, as
+// just computing Difficulty(event.GetID()) the slow way.
+func TestNonceMinerMatchesDifficulty(t *testing.T) {
+	for _, targetDifficulty := range []int{0, 8, 16} {
+		event := testEvent()
+		miner, tag := newNonceMiner(event, targetDifficulty)
+
+		for nonce := uint64(0); nonce < 2000; nonce++ {
+			tag[1] = strconv.FormatUint(nonce, 10)
+
+			want := Difficulty(event.GetID())
+			got := miner.difficulty(nonce)
+			if got != want {
+				t.Fatalf("targetDifficulty %d, nonce %d: miner.difficulty = %d, want %d", targetDifficulty, nonce, got, want)
+			}
+		}
+	}
+}
+
+func TestDifficultyBytesMatchesDifficulty(t *testing.T) {
+	event := testEvent()
+	id := event.GetID()
+
+	raw, err := hex.DecodeString(id)
+	if err != nil {
+		t.Fatalf("decoding event id: %v", err)
+	}
+	var digest [32]byte
+	copy(digest[:], raw)
+
+	if got, want := DifficultyBytes(digest), Difficulty(id); got != want {
+		t.Fatalf("DifficultyBytes = %d, want %d (from Difficulty)", got, want)
+	}
+}
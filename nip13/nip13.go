@@ -35,7 +35,14 @@ func CommittedDifficulty(event *nostr.Event) int {
 }
 
 // Difficulty counts the number of leading zero bits in an event ID.
+// An id that isn't 64 characters long (as happens when an event's ID was
+// never populated) can't be measured and returns -1, the same sentinel
+// already used below for a byte that fails to decode as hex.
 func Difficulty(id string) int {
+	if len(id) != 64 {
+		return -1
+	}
+
 	var zeros int
 	var b [1]byte
 	for i := 0; i < 64; i += 2 {
@@ -74,14 +81,14 @@ func Generate(event *nostr.Event, targetDifficulty int, timeout time.Duration) (
 		return nil, ErrMissingPubKey
 	}
 
-	tag := nostr.Tag{"nonce", "", strconv.Itoa(targetDifficulty)}
-	event.Tags = append(event.Tags, tag)
+	miner, tag := newNonceMiner(event, targetDifficulty)
+
 	var nonce uint64
 	start := time.Now()
 	for {
 		nonce++
-		tag[1] = uintToStringCrazy(nonce)
-		if Difficulty(event.GetID()) >= targetDifficulty {
+		if miner.difficulty(nonce) >= targetDifficulty {
+			tag[1] = strconv.FormatUint(nonce, 10)
 			return event, nil
 		}
 		// benchmarks show one iteration is approx 3000ns on i7-8565U @ 1.8GHz.
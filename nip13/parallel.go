@@ -0,0 +1,99 @@
+package nip13
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"sync"
+
+	nostr "github.com/nbd-wtf/go-nostr"
+)
+
+// GenerateWithContext is like Generate, but instead of a timeout it takes a context
+// and searches for a qualifying nonce using multiple goroutines in parallel.
+//
+// The nonce space is partitioned across workers so that worker w only tries
+// nonces of the form nonce = k*workers + w, guaranteeing every worker probes a
+// disjoint stride and no nonce is attempted twice. If workers <= 0,
+// runtime.NumCPU() is used.
+//
+// The first worker to find a qualifying event wins and every other worker is
+// stopped. Like Generate, GenerateWithContext mutates the passed event in
+// place: on success event.Tags is updated with the winning "nonce" tag and
+// event is returned. If ctx is cancelled or reaches its deadline before any
+// worker finds a solution, GenerateWithContext leaves event untouched and
+// returns ctx.Err().
+func GenerateWithContext(ctx context.Context, event *nostr.Event, targetDifficulty int, workers int) (*nostr.Event, error) {
+	if event.PubKey == "" {
+		return nil, ErrMissingPubKey
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Snapshot event and event.Tags once, synchronously, before any worker
+	// starts: workers read these snapshots instead of event directly, so the
+	// later event.Tags = tags write on the winning path never races a worker
+	// that's still copying its own starting event and tags.
+	snapshot := make(nostr.Tags, len(event.Tags))
+	copy(snapshot, event.Tags)
+	base := *event
+
+	found := make(chan nostr.Tags, 1)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w uint64) {
+			defer wg.Done()
+
+			local := base
+			local.Tags = make(nostr.Tags, len(snapshot), len(snapshot)+1)
+			copy(local.Tags, snapshot)
+			miner, tag := newNonceMiner(&local, targetDifficulty)
+
+			workers := uint64(workers)
+			for nonce := w; ; nonce += workers {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+
+				if miner.difficulty(nonce) >= targetDifficulty {
+					tag[1] = strconv.FormatUint(nonce, 10)
+					select {
+					case found <- local.Tags:
+						cancel()
+					default:
+					}
+					return
+				}
+			}
+		}(uint64(w))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case tags := <-found:
+		event.Tags = tags
+		return event, nil
+	case <-ctx.Done():
+		select {
+		case tags := <-found:
+			event.Tags = tags
+			return event, nil
+		default:
+		}
+		<-done
+		return nil, ctx.Err()
+	}
+}
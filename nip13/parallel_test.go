@@ -0,0 +1,44 @@
+package nip13
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestGenerateWithContextFindsQualifyingNonce exercises the full worker
+// fan-out/winner-takes-all path under -race: a low target difficulty makes
+// it likely more than one worker is still starting up when the first finds
+// a qualifying nonce, which is exactly the window that used to race on
+// event.Tags.
+func TestGenerateWithContextFindsQualifyingNonce(t *testing.T) {
+	event := testEvent()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	got, err := GenerateWithContext(ctx, event, 8, 4)
+	if err != nil {
+		t.Fatalf("GenerateWithContext: %v", err)
+	}
+	if got != event {
+		t.Fatal("GenerateWithContext returned a different *Event than the one passed in")
+	}
+	if d := Difficulty(event.GetID()); d < 8 {
+		t.Fatalf("resulting event has difficulty %d, want >= 8", d)
+	}
+}
+
+// TestGenerateWithContextRespectsCancellation picks a target difficulty no
+// worker can realistically reach within the deadline, and checks the
+// deadline wins cleanly rather than hanging or racing.
+func TestGenerateWithContextRespectsCancellation(t *testing.T) {
+	event := testEvent()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, err := GenerateWithContext(ctx, event, 32, 4); err != context.DeadlineExceeded {
+		t.Fatalf("GenerateWithContext = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,205 @@
+package nip13
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	nostr "github.com/nbd-wtf/go-nostr"
+)
+
+// ErrPoolClosed is returned by Submit for jobs submitted after, or racing,
+// a call to Close, and for jobs a worker was mining when Close cut them short.
+var ErrPoolClosed = errors.New("nip13: pool is closed")
+
+// Result is delivered on the channel returned by Pool.Submit once a job
+// finishes: Err is nil when a qualifying nonce was found, ctx.Err() when the
+// job's context was cancelled or deadlined first, ErrPoolClosed when the Pool
+// was closed first, and ErrMissingPubKey when the event has no pubkey.
+type Result struct {
+	Event *nostr.Event
+	Err   error
+}
+
+// job is one unit of work queued on a Pool.
+type job struct {
+	ctx        context.Context
+	event      *nostr.Event
+	difficulty int
+	result     chan<- Result
+}
+
+// Pool is a fixed-size pool of mining workers shared across many outbound
+// events, e.g. to satisfy a relay's NIP-11 min_pow_difficulty before
+// publishing to it. Each worker mines one job at a time using the same
+// pre-hashed nonceMiner core as Generate, so jobs queued on a busy Pool wait
+// rather than oversubscribing the machine with extra goroutines.
+//
+// Submit and Close are both safe to call concurrently, from any number of
+// goroutines: a Submit racing a Close either gets queued before Close takes
+// effect or fails with ErrPoolClosed, it never panics.
+type Pool struct {
+	defaultDifficulty int
+	jobs              chan job
+	closed            chan struct{}
+	closeOnce         sync.Once
+	wg                sync.WaitGroup
+
+	hashes   atomic.Uint64
+	inFlight atomic.Int64
+
+	statsMu    sync.Mutex
+	lastStats  time.Time
+	lastHashes uint64
+}
+
+// NewPool starts a Pool with the given number of workers (runtime.NumCPU()
+// if workers <= 0). defaultDifficulty is used by Submit whenever a caller
+// passes a targetDifficulty <= 0.
+func NewPool(workers int, defaultDifficulty int) *Pool {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	p := &Pool{
+		defaultDifficulty: defaultDifficulty,
+		jobs:              make(chan job),
+		closed:            make(chan struct{}),
+		lastStats:         time.Now(),
+	}
+
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+
+	return p
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for {
+		select {
+		case j := <-p.jobs:
+			p.mine(j)
+		case <-p.closed:
+			return
+		}
+	}
+}
+
+func (p *Pool) mine(j job) {
+	// the job stops counting as in flight before its Result is sent, so a
+	// caller that has received the Result no longer sees it in Stats.
+	finish := func(r Result) {
+		p.inFlight.Add(-1)
+		j.result <- r
+	}
+
+	if j.event.PubKey == "" {
+		finish(Result{Err: ErrMissingPubKey})
+		return
+	}
+
+	miner, tag := newNonceMiner(j.event, j.difficulty)
+
+	var nonce uint64
+	for {
+		nonce++
+
+		select {
+		case <-j.ctx.Done():
+			finish(Result{Err: j.ctx.Err()})
+			return
+		case <-p.closed:
+			finish(Result{Err: ErrPoolClosed})
+			return
+		default:
+		}
+
+		p.hashes.Add(1)
+		if miner.difficulty(nonce) >= j.difficulty {
+			tag[1] = strconv.FormatUint(nonce, 10)
+			finish(Result{Event: j.event})
+			return
+		}
+	}
+}
+
+// Submit queues event for mining to targetDifficulty (the Pool's
+// defaultDifficulty if targetDifficulty <= 0) and returns a channel that
+// receives exactly one Result once a worker picks it up and finishes. The
+// job honors ctx: if it is cancelled or deadlined, possibly before a worker
+// even reaches it, mining stops and the Result carries ctx.Err(), so a
+// caller can drop work for e.g. a relay that just disconnected.
+//
+// If the Pool has already been, or concurrently is being, closed, Submit
+// returns a Result with ErrPoolClosed instead of queueing the job.
+func (p *Pool) Submit(ctx context.Context, event *nostr.Event, targetDifficulty int) <-chan Result {
+	if targetDifficulty <= 0 {
+		targetDifficulty = p.defaultDifficulty
+	}
+
+	result := make(chan Result, 1)
+
+	p.inFlight.Add(1)
+	select {
+	case p.jobs <- job{ctx: ctx, event: event, difficulty: targetDifficulty, result: result}:
+	case <-ctx.Done():
+		p.inFlight.Add(-1)
+		result <- Result{Err: ctx.Err()}
+	case <-p.closed:
+		p.inFlight.Add(-1)
+		result <- Result{Err: ErrPoolClosed}
+	}
+
+	return result
+}
+
+// Stats reports the Pool's hash rate and how many jobs are currently queued
+// or being mined, so applications can display mining progress in a UI.
+type Stats struct {
+	HashesPerSec float64
+	JobsInFlight int
+}
+
+// Stats returns the Pool's hashes/sec measured since the previous call to
+// Stats (or since the Pool was created, for the first call), so it reflects
+// current throughput rather than a lifetime average dragged down by any
+// idle gaps between jobs.
+func (p *Pool) Stats() Stats {
+	now := time.Now()
+	hashes := p.hashes.Load()
+
+	p.statsMu.Lock()
+	elapsed := now.Sub(p.lastStats).Seconds()
+	delta := hashes - p.lastHashes
+	p.lastStats = now
+	p.lastHashes = hashes
+	p.statsMu.Unlock()
+
+	var rate float64
+	if elapsed > 0 {
+		rate = float64(delta) / elapsed
+	}
+
+	return Stats{
+		HashesPerSec: rate,
+		JobsInFlight: int(p.inFlight.Load()),
+	}
+}
+
+// Close stops accepting new jobs, cuts short any job a worker is mining (its
+// Result carries ErrPoolClosed) and waits for every worker to return before
+// returning itself. It is safe to call Close more than once,
+// and safe to call concurrently with Submit.
+func (p *Pool) Close() {
+	p.closeOnce.Do(func() {
+		close(p.closed)
+	})
+	p.wg.Wait()
+}
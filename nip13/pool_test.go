@@ -0,0 +1,63 @@
+package nip13
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPoolSubmitMinesToTarget(t *testing.T) {
+	pool := NewPool(2, 8)
+	defer pool.Close()
+
+	result := <-pool.Submit(context.Background(), testEvent(), 8)
+	if result.Err != nil {
+		t.Fatalf("Submit result: %v", result.Err)
+	}
+	if d := Difficulty(result.Event.GetID()); d < 8 {
+		t.Fatalf("resulting event has difficulty %d, want >= 8", d)
+	}
+
+	if stats := pool.Stats(); stats.JobsInFlight != 0 {
+		t.Fatalf("JobsInFlight = %d, want 0 once the job has completed", stats.JobsInFlight)
+	}
+}
+
+// TestPoolCloseUnblocksInFlightJob submits a job with context.Background()
+// (no deadline) at a difficulty it won't reach, then closes the pool: Close
+// must still return, which only happens if mine's loop also watches for the
+// pool being closed instead of only the job's own context.
+func TestPoolCloseUnblocksInFlightJob(t *testing.T) {
+	pool := NewPool(1, 0)
+
+	results := pool.Submit(context.Background(), testEvent(), 32)
+
+	// give the lone worker a moment to pick up the job before closing.
+	time.Sleep(10 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		pool.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not return: an in-flight job with no deadline deadlocked it")
+	}
+
+	if result := <-results; result.Err == nil {
+		t.Fatal("expected an error from a job cut short by Close, got a nil error")
+	}
+}
+
+func TestPoolSubmitAfterCloseFailsFast(t *testing.T) {
+	pool := NewPool(1, 0)
+	pool.Close()
+
+	result := <-pool.Submit(context.Background(), testEvent(), 8)
+	if result.Err != ErrPoolClosed {
+		t.Fatalf("Submit after Close = %v, want ErrPoolClosed", result.Err)
+	}
+}
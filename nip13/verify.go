@@ -0,0 +1,53 @@
+package nip13
+
+import (
+	"errors"
+	"strconv"
+
+	nostr "github.com/nbd-wtf/go-nostr"
+)
+
+var (
+	ErrMissingCommitment     = errors.New("nip13: event does not commit to a target difficulty")
+	ErrCommitmentBelowTarget = errors.New("nip13: event commits to a target difficulty below the required minimum")
+	ErrInvalidSignature      = errors.New("nip13: event has an invalid signature")
+)
+
+// Verify checks that event satisfies NIP-13's proof-of-work requirements: it
+// must carry a valid signature and its ID must have at least minDifficulty
+// leading zero bits.
+//
+// When requireCommitment is true, Verify additionally requires a "nonce" tag
+// whose third element parses as an integer >= minDifficulty, so that a
+// reposted or cherry-picked event that merely got lucky (without actually
+// committing to the work) is rejected with ErrMissingCommitment or
+// ErrCommitmentBelowTarget instead of being accepted just because its ID
+// happens to qualify.
+func Verify(event *nostr.Event, minDifficulty int, requireCommitment bool) error {
+	if ok, err := event.CheckSignature(); !ok {
+		if err != nil {
+			return err
+		}
+		return ErrInvalidSignature
+	}
+
+	if err := Check(event.ID, minDifficulty); err != nil {
+		return err
+	}
+
+	if requireCommitment {
+		nonceTag := event.Tags.GetFirst([]string{"nonce", ""})
+		if nonceTag == nil || len(*nonceTag) < 3 {
+			return ErrMissingCommitment
+		}
+		target, err := strconv.Atoi((*nonceTag)[2])
+		if err != nil {
+			return ErrMissingCommitment
+		}
+		if target < minDifficulty {
+			return ErrCommitmentBelowTarget
+		}
+	}
+
+	return nil
+}